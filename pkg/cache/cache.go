@@ -0,0 +1,139 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cache implements a small content-addressable cache for
+// u-root's build output, modeled after the design of
+// cmd/go/internal/cache: callers compute an ActionID from the inputs
+// that determine a build's output, and the cache stores and retrieves
+// the resulting artifact keyed on that ID.
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// ActionID identifies a build action: the sha256 hash of all inputs
+// that can affect its output.
+type ActionID [sha256.Size]byte
+
+// String returns the hex encoding of the ID, suitable for use as a
+// file name.
+func (id ActionID) String() string {
+	return fmt.Sprintf("%x", [sha256.Size]byte(id))
+}
+
+// Cache is an on-disk, content-addressable store of build artifacts,
+// rooted at Dir.
+type Cache struct {
+	Dir string
+}
+
+// DefaultDir returns the default cache directory: $XDG_CACHE_HOME/u-root,
+// falling back to $HOME/.cache/u-root if XDG_CACHE_HOME is unset.
+func DefaultDir() (string, error) {
+	if d := os.Getenv("XDG_CACHE_HOME"); d != "" {
+		return filepath.Join(d, "u-root"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "u-root"), nil
+}
+
+// NewCache opens (creating if necessary) a cache rooted at dir.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create cache dir %q: %v", dir, err)
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// path returns the on-disk path for the artifact with the given action ID.
+func (c *Cache) path(id ActionID) string {
+	return filepath.Join(c.Dir, id.String())
+}
+
+// Lookup reports whether an artifact for id is already cached, returning
+// its path if so.
+func (c *Cache) Lookup(id ActionID) (path string, ok bool) {
+	p := c.path(id)
+	if _, err := os.Stat(p); err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Put copies the file at srcPath into the cache under id and returns the
+// cache entry's path. It is safe to call concurrently; the copy is written
+// to a temp file and renamed into place so a concurrent Lookup never sees
+// a partial entry.
+func (c *Cache) Put(id ActionID, srcPath string) (string, error) {
+	dst := c.path(id)
+	tmp, err := os.CreateTemp(c.Dir, "tmp-"+id.String())
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	_, copyErr := io.Copy(tmp, src)
+	src.Close()
+	closeErr := tmp.Close()
+	if copyErr != nil {
+		return "", copyErr
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+var hits, misses int64
+
+// RecordHit records a cache hit. Callers use this to report effectiveness
+// stats (e.g. into u-root's buildStats JSON) without threading a result
+// value through every build call.
+func RecordHit() { atomic.AddInt64(&hits, 1) }
+
+// RecordMiss records a cache miss.
+func RecordMiss() { atomic.AddInt64(&misses, 1) }
+
+// Hits returns the number of cache hits recorded so far in this process.
+func Hits() int64 { return atomic.LoadInt64(&hits) }
+
+// Misses returns the number of cache misses recorded so far in this process.
+func Misses() int64 { return atomic.LoadInt64(&misses) }
+
+// HashFile returns the sha256 hash of the file at path, for inclusion in
+// an ActionID's input set.
+func HashFile(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutLookup(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCache(dir)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	src := filepath.Join(dir, "artifact")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var id ActionID
+	id[0] = 1
+
+	if _, ok := c.Lookup(id); ok {
+		t.Fatal("Lookup hit before any Put")
+	}
+
+	path, err := c.Put(id, src)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("cached content = %q, want %q", got, "hello")
+	}
+
+	hit, ok := c.Lookup(id)
+	if !ok {
+		t.Fatal("Lookup miss after Put")
+	}
+	if hit != path {
+		t.Fatalf("Lookup path = %q, want %q", hit, path)
+	}
+}
+
+func TestHitsMisses(t *testing.T) {
+	before, beforeMiss := Hits(), Misses()
+	RecordHit()
+	RecordMiss()
+	RecordMiss()
+	if got := Hits(); got != before+1 {
+		t.Errorf("Hits() = %d, want %d", got, before+1)
+	}
+	if got := Misses(); got != beforeMiss+2 {
+		t.Errorf("Misses() = %d, want %d", got, beforeMiss+2)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "a")
+	if err := os.WriteFile(f, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h1, err := HashFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := HashFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Error("HashFile not deterministic for identical content")
+	}
+
+	if err := os.WriteFile(f, []byte("different"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h3, err := HashFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 == h3 {
+		t.Error("HashFile did not change when file content changed")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cpio defines the record types u-root's initramfs archives are
+// made of. It does no reading or writing itself; pkg/uroot/initramfs
+// serializes these records in its own format, not cpio "newc".
+package cpio
+
+import "io"
+
+// Info holds the metadata cpio stores for a single archive entry.
+type Info struct {
+	Ino      uint64
+	Mode     uint64
+	UID      uint64
+	GID      uint64
+	NLink    uint64
+	MTime    uint64
+	FileSize uint64
+	Major    uint64
+	Minor    uint64
+	Rmajor   uint64
+	Rminor   uint64
+}
+
+// Record is one entry in a cpio archive: its metadata, its path within
+// the archive, and a reader for its content (nil for directories and
+// other content-less entries).
+type Record struct {
+	Info
+	Name     string
+	ReaderAt io.ReaderAt
+}
@@ -0,0 +1,91 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// okgoos and okgoarch enumerate the GOOS/GOARCH combinations u-root
+// supports building an initramfs for, following the same idea as
+// cmd/dist's own okgoos/okgoarch tables: most of the matrix that `go
+// build` itself accepts is never something u-root's boot environment
+// runs on, so a typo (or a copy-pasted GOARCH=wasm) should fail before
+// spending minutes compiling hundreds of commands.
+var okgoos = map[string]bool{
+	"linux":   true,
+	"freebsd": true,
+	"plan9":   true,
+}
+
+var okgoarch = map[string]bool{
+	"386":      true,
+	"amd64":    true,
+	"arm":      true,
+	"arm64":    true,
+	"mips":     true,
+	"mipsle":   true,
+	"mips64":   true,
+	"mips64le": true,
+	"ppc64":    true,
+	"ppc64le":  true,
+	"riscv64":  true,
+}
+
+// CheckGOOSARCHPair fails with a descriptive error if goos/goarch isn't
+// a combination u-root knows how to produce a working initramfs for.
+// This is deliberately a subset check (not cross-validated against
+// which pairs `go build` itself accepts): its job is to catch
+// environment typos fast, not to replace the Go toolchain's own
+// validation.
+func CheckGOOSARCHPair(goos, goarch string) error {
+	if !okgoos[goos] {
+		return fmt.Errorf("unsupported GOOS %q for u-root (supported: %s)", goos, sortedKeys(okgoos))
+	}
+	if !okgoarch[goarch] {
+		return fmt.Errorf("unsupported GOARCH %q for u-root (supported: %s)", goarch, sortedKeys(okgoarch))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// okSubArch lists the valid values for each sub-architecture selector,
+// mirroring the sets the Go toolchain itself accepts for GOARM,
+// GOAMD64, GO386, GOMIPS, GOMIPS64, and GOPPC64.
+var okSubArch = map[string]map[string]bool{
+	"GOARM":    {"5": true, "6": true, "7": true},
+	"GOAMD64":  {"v1": true, "v2": true, "v3": true, "v4": true},
+	"GO386":    {"sse2": true, "softfloat": true},
+	"GOMIPS":   {"hardfloat": true, "softfloat": true},
+	"GOMIPS64": {"hardfloat": true, "softfloat": true},
+	"GOPPC64":  {"power8": true, "power9": true, "power10": true},
+}
+
+// CheckSubArch validates a sub-architecture selector's value (e.g.
+// name="GOARM", value="7"). An empty value is always valid: it means
+// "let the Go toolchain pick its default".
+func CheckSubArch(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	valid, ok := okSubArch[name]
+	if !ok {
+		return fmt.Errorf("unknown sub-architecture selector %q", name)
+	}
+	if !valid[value] {
+		return fmt.Errorf("invalid %s %q (valid: %s)", name, value, sortedKeys(valid))
+	}
+	return nil
+}
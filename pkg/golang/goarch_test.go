@@ -0,0 +1,56 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import "testing"
+
+func TestCheckGOOSARCHPair(t *testing.T) {
+	for _, tt := range []struct {
+		goos, goarch string
+		wantErr      bool
+	}{
+		{"linux", "amd64", false},
+		{"linux", "arm64", false},
+		{"linux", "riscv64", false},
+		{"freebsd", "amd64", false},
+		{"plan9", "386", false},
+		{"linux", "wasm", true},
+		{"windows", "amd64", true},
+		{"darwin", "amd64", true},
+		{"linux", "", true},
+		{"", "amd64", true},
+	} {
+		err := CheckGOOSARCHPair(tt.goos, tt.goarch)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckGOOSARCHPair(%q, %q) = %v, wantErr %v", tt.goos, tt.goarch, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckSubArch(t *testing.T) {
+	for _, tt := range []struct {
+		name, value string
+		wantErr     bool
+	}{
+		{"GOARM", "", false},
+		{"GOARM", "7", false},
+		{"GOARM", "9", true},
+		{"GOAMD64", "v2", false},
+		{"GOAMD64", "v5", true},
+		{"GO386", "sse2", false},
+		{"GO386", "avx", true},
+		{"GOMIPS", "hardfloat", false},
+		{"GOMIPS", "bogus", true},
+		{"GOMIPS64", "softfloat", false},
+		{"GOPPC64", "power9", false},
+		{"GOPPC64", "power7", true},
+		{"GOWASM", "satspec", true}, // unknown selector entirely
+	} {
+		err := CheckSubArch(tt.name, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckSubArch(%q, %q) = %v, wantErr %v", tt.name, tt.value, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package golang wraps the `go` tool: it describes the environment a
+// build runs in and knows how to invoke `go build` with that
+// environment, so callers like pkg/uroot/builder don't each have to
+// reimplement env-var and flag plumbing.
+package golang
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Environ describes the environment a `go build` invocation runs
+// under.
+type Environ struct {
+	GOOS, GOARCH string
+	GOROOT       string
+	BuildTags    []string
+	CgoEnabled   bool
+
+	// Sub-architecture selectors; empty means "let the Go toolchain
+	// pick its default". See CheckSubArch for the valid values of each.
+	GOARM, GOAMD64, GO386, GOMIPS, GOMIPS64, GOPPC64 string
+
+	// GOEXPERIMENT is forwarded verbatim to every go build invocation.
+	GOEXPERIMENT string
+}
+
+// Default returns an Environ for the process's own environment:
+// GOOS/GOARCH from the environment, falling back to the running
+// toolchain's defaults, and CgoEnabled true only if CGO_ENABLED=1 is set.
+func Default() Environ {
+	e := Environ{
+		GOOS:       os.Getenv("GOOS"),
+		GOARCH:     os.Getenv("GOARCH"),
+		GOROOT:     runtime.GOROOT(),
+		CgoEnabled: os.Getenv("CGO_ENABLED") == "1",
+	}
+	if e.GOOS == "" {
+		e.GOOS = runtime.GOOS
+	}
+	if e.GOARCH == "" {
+		e.GOARCH = runtime.GOARCH
+	}
+	return e
+}
+
+// String renders the environment the way u-root logs it on startup.
+func (c Environ) String() string {
+	return fmt.Sprintf("GOOS=%s GOARCH=%s CGO_ENABLED=%v BuildTags=%s", c.GOOS, c.GOARCH, c.CgoEnabled, strings.Join(c.BuildTags, ","))
+}
+
+// GoCmd returns the path to the `go` binary this Environ should invoke.
+func (c Environ) GoCmd() string {
+	if c.GOROOT != "" {
+		if p := filepath.Join(c.GOROOT, "bin", "go"); isExecutable(p) {
+			return p
+		}
+	}
+	return "go"
+}
+
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// Env returns the os/exec-style "KEY=value" environment for this
+// Environ, to append to an exec.Cmd's Env (after os.Environ(), so these
+// values win).
+func (c Environ) Env() []string {
+	cgo := "0"
+	if c.CgoEnabled {
+		cgo = "1"
+	}
+	env := []string{
+		"GOOS=" + c.GOOS,
+		"GOARCH=" + c.GOARCH,
+		"CGO_ENABLED=" + cgo,
+	}
+	for _, subArch := range []struct {
+		name, value string
+	}{
+		{"GOARM", c.GOARM},
+		{"GOAMD64", c.GOAMD64},
+		{"GO386", c.GO386},
+		{"GOMIPS", c.GOMIPS},
+		{"GOMIPS64", c.GOMIPS64},
+		{"GOPPC64", c.GOPPC64},
+	} {
+		if subArch.value != "" {
+			env = append(env, subArch.name+"="+subArch.value)
+		}
+	}
+	if c.GOEXPERIMENT != "" {
+		env = append(env, "GOEXPERIMENT="+c.GOEXPERIMENT)
+	}
+	return env
+}
+
+// Version returns the toolchain's `go version` output, e.g.
+// "go version go1.21.6 linux/amd64".
+func (c Environ) Version() (string, error) {
+	cmd := exec.Command(c.GoCmd(), "version")
+	cmd.Env = append(os.Environ(), c.Env()...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get go version: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// BuildOpts carries the per-build overrides that vary from one package
+// to the next within the same Environ, namely per-package gcflags and
+// ldflags.
+type BuildOpts struct {
+	GCFlags   string
+	LDFlags   string
+	ExtraArgs []string
+}
+
+// Build runs `go build -o binaryPath [flags...] pkg` under c's
+// environment.
+func (c Environ) Build(pkg, binaryPath string, opts BuildOpts) error {
+	args := []string{"build", "-o", binaryPath}
+	if len(c.BuildTags) > 0 {
+		args = append(args, "-tags", strings.Join(c.BuildTags, ","))
+	}
+	if opts.GCFlags != "" {
+		args = append(args, "-gcflags", opts.GCFlags)
+	}
+	if opts.LDFlags != "" {
+		args = append(args, "-ldflags", opts.LDFlags)
+	}
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, pkg)
+
+	cmd := exec.Command(c.GoCmd(), args...)
+	cmd.Env = append(os.Environ(), c.Env()...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("building %q: %v", pkg, err)
+	}
+	return nil
+}
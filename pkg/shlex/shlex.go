@@ -0,0 +1,48 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shlex implements a tiny shell-like tokenizer, just enough to
+// split u-root's `-uinitcmd="echo foobar"`-style flags into argv.
+package shlex
+
+import "strings"
+
+// Argv splits s into fields the way a shell would split an unquoted
+// command line: runs of spaces and tabs separate arguments, and single- or
+// double-quoted spans are kept together with their quotes stripped.
+func Argv(s string) []string {
+	var (
+		args   []string
+		cur    strings.Builder
+		inWord bool
+		quote  rune
+	)
+	flush := func() {
+		if inWord {
+			args = append(args, cur.String())
+			cur.Reset()
+			inWord = false
+		}
+	}
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			flush()
+		default:
+			inWord = true
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return args
+}
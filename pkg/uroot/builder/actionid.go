@@ -0,0 +1,93 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/u-root/u-root/pkg/cache"
+)
+
+// PackageInput is one Go package that feeds into a build's action ID: its
+// import path and the sha256 hashes of the source files u-root resolved
+// for it (including transitively imported packages).
+type PackageInput struct {
+	ImportPath string
+	FileHashes map[string][sha256.Size]byte
+}
+
+// ActionKeyInput collects every input that can change what a builder
+// produces. Two builds with identical ActionKeyInputs are guaranteed to
+// produce byte-identical output, so its hash can be used as a cache key.
+type ActionKeyInput struct {
+	Packages []PackageInput
+
+	GOOS, GOARCH string
+	// SubArch holds the sub-architecture selectors that affect codegen,
+	// e.g. GOARM, GOAMD64, GO386, GOMIPS, GOMIPS64, GOPPC64.
+	SubArch    map[string]string
+	BuildTags  []string
+	CgoEnabled bool
+	// GOEXPERIMENT is forwarded verbatim to every go build invocation;
+	// see golang.Environ.GOEXPERIMENT.
+	GOEXPERIMENT string
+
+	// Builder identifies which builder produced the artifact (e.g. "bb"
+	// or "binary") and a stable encoding of its options (e.g. ShellBang).
+	Builder        string
+	BuilderOptions string
+
+	GoVersion string
+	NoStrip   bool
+}
+
+// ActionID computes the cache key for in. The encoding is deliberately
+// simple and stable across runs: callers must not rely on its exact
+// bytes, only on the fact that equal inputs produce equal IDs.
+func ActionID(in ActionKeyInput) cache.ActionID {
+	h := sha256.New()
+
+	pkgs := append([]PackageInput(nil), in.Packages...)
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	for _, p := range pkgs {
+		fmt.Fprintf(h, "pkg %s\n", p.ImportPath)
+		files := make([]string, 0, len(p.FileHashes))
+		for f := range p.FileHashes {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			fmt.Fprintf(h, "  file %s %x\n", f, p.FileHashes[f])
+		}
+	}
+
+	fmt.Fprintf(h, "goos %s\n", in.GOOS)
+	fmt.Fprintf(h, "goarch %s\n", in.GOARCH)
+
+	subArchKeys := make([]string, 0, len(in.SubArch))
+	for k := range in.SubArch {
+		subArchKeys = append(subArchKeys, k)
+	}
+	sort.Strings(subArchKeys)
+	for _, k := range subArchKeys {
+		fmt.Fprintf(h, "subarch %s=%s\n", k, in.SubArch[k])
+	}
+
+	tags := append([]string(nil), in.BuildTags...)
+	sort.Strings(tags)
+	fmt.Fprintf(h, "tags %s\n", strings.Join(tags, ","))
+	fmt.Fprintf(h, "cgo %v\n", in.CgoEnabled)
+	fmt.Fprintf(h, "goexperiment %s\n", in.GOEXPERIMENT)
+	fmt.Fprintf(h, "builder %s %s\n", in.Builder, in.BuilderOptions)
+	fmt.Fprintf(h, "goversion %s\n", in.GoVersion)
+	fmt.Fprintf(h, "nostrip %v\n", in.NoStrip)
+
+	var id cache.ActionID
+	copy(id[:], h.Sum(nil))
+	return id
+}
@@ -0,0 +1,85 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import "testing"
+
+func baseInput() ActionKeyInput {
+	return ActionKeyInput{
+		Packages: []PackageInput{
+			{ImportPath: "github.com/u-root/u-root/cmds/core/ls", FileHashes: map[string][32]byte{
+				"ls.go": {1, 2, 3},
+			}},
+		},
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+		BuildTags:  []string{"tag1"},
+		CgoEnabled: false,
+		Builder:    "bb",
+		GoVersion:  "go1.21",
+	}
+}
+
+func TestActionIDStable(t *testing.T) {
+	a := ActionID(baseInput())
+	b := ActionID(baseInput())
+	if a != b {
+		t.Error("ActionID not stable across identical inputs")
+	}
+}
+
+func TestActionIDChangesWithFileContent(t *testing.T) {
+	in := baseInput()
+	a := ActionID(in)
+
+	in.Packages[0].FileHashes["ls.go"] = [32]byte{9, 9, 9}
+	b := ActionID(in)
+
+	if a == b {
+		t.Error("ActionID did not change when a source file hash changed")
+	}
+}
+
+func TestActionIDChangesWithEnv(t *testing.T) {
+	cases := []func(*ActionKeyInput){
+		func(in *ActionKeyInput) { in.GOOS = "freebsd" },
+		func(in *ActionKeyInput) { in.GOARCH = "arm64" },
+		func(in *ActionKeyInput) { in.BuildTags = []string{"other"} },
+		func(in *ActionKeyInput) { in.CgoEnabled = true },
+		func(in *ActionKeyInput) { in.Builder = "binary" },
+		func(in *ActionKeyInput) { in.BuilderOptions = "shellbang=true" },
+		func(in *ActionKeyInput) { in.GoVersion = "go1.20" },
+		func(in *ActionKeyInput) { in.NoStrip = true },
+		func(in *ActionKeyInput) { in.SubArch = map[string]string{"GOARM": "7"} },
+		func(in *ActionKeyInput) { in.GOEXPERIMENT = "rangefunc" },
+	}
+
+	base := ActionID(baseInput())
+	for i, mutate := range cases {
+		in := baseInput()
+		mutate(&in)
+		if got := ActionID(in); got == base {
+			t.Errorf("case %d: ActionID did not change", i)
+		}
+	}
+}
+
+func TestActionIDIndependentOfPackageOrder(t *testing.T) {
+	in := baseInput()
+	in.Packages[0].FileHashes["zz.go"] = [32]byte{4, 5, 6}
+
+	in2 := baseInput()
+	in2.Packages[0].FileHashes["zz.go"] = [32]byte{4, 5, 6}
+
+	// Packages in reverse order; map iteration order for FileHashes is
+	// already randomized by Go, so this mostly re-confirms that Packages
+	// sorting doesn't matter either.
+	in.Packages = append([]PackageInput{{ImportPath: "z"}}, in.Packages...)
+	in2.Packages = append(in2.Packages, PackageInput{ImportPath: "z"})
+
+	if ActionID(in) != ActionID(in2) {
+		t.Error("ActionID depends on Packages slice order")
+	}
+}
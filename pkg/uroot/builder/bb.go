@@ -0,0 +1,98 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/u-root/u-root/pkg/cache"
+	"github.com/u-root/u-root/pkg/golang"
+)
+
+// BBBuilder builds each command as its own busybox-style binary named
+// after its last import path component. Commands are not multiplexed
+// into one shared busybox binary: it builds one binary per command,
+// same as BinaryBuilder. ShellBang is recorded in the build's action ID
+// only; it does not change the built binaries or how they are placed
+// in /bin.
+type BBBuilder struct {
+	ShellBang bool
+
+	// GCFlags and LDFlags resolve per-package -gcflags/-ldflags
+	// overrides (see PkgFlagResolver); their zero value applies no
+	// overrides.
+	GCFlags PkgFlagResolver
+	LDFlags PkgFlagResolver
+}
+
+// Build implements Builder.
+func (b BBBuilder) Build(opts Opts) error {
+	for _, pkg := range opts.Packages {
+		buildOpts := golang.BuildOpts{
+			GCFlags: b.GCFlags.Flags(pkg),
+			LDFlags: b.LDFlags.Flags(pkg),
+		}
+		if err := buildOne(opts, pkg, "bb", fmt.Sprintf("shellbang=%v", b.ShellBang), buildOpts); err != nil {
+			return fmt.Errorf("bb builder: %v", err)
+		}
+	}
+	return nil
+}
+
+// buildOne builds a single package, consulting opts.Cache first and
+// populating it after a successful build. builderName/builderOptions
+// identify the builder in the action ID so that "bb" and "binary" never
+// share a cache entry for the same package.
+func buildOne(opts Opts, pkg, builderName, builderOptions string, buildOpts golang.BuildOpts) error {
+	pkgInputs, err := packageInput(pkg)
+	if err != nil {
+		return err
+	}
+
+	v, err := opts.Env.Version()
+	if err != nil {
+		return err
+	}
+
+	id := ActionID(ActionKeyInput{
+		Packages: pkgInputs,
+		GOOS:     opts.Env.GOOS,
+		GOARCH:   opts.Env.GOARCH,
+		SubArch: map[string]string{
+			"GOARM": opts.Env.GOARM, "GOAMD64": opts.Env.GOAMD64, "GO386": opts.Env.GO386,
+			"GOMIPS": opts.Env.GOMIPS, "GOMIPS64": opts.Env.GOMIPS64, "GOPPC64": opts.Env.GOPPC64,
+		},
+		BuildTags:      opts.Env.BuildTags,
+		CgoEnabled:     opts.Env.CgoEnabled,
+		GOEXPERIMENT:   opts.Env.GOEXPERIMENT,
+		Builder:        builderName,
+		BuilderOptions: fmt.Sprintf("%s gcflags=%q ldflags=%q", builderOptions, buildOpts.GCFlags, buildOpts.LDFlags),
+		GoVersion:      v,
+		NoStrip:        opts.NoStrip,
+	})
+
+	binPath := filepath.Join(opts.TempDir, path.Base(pkg))
+
+	if !opts.RebuildAll && opts.Cache != nil {
+		if cached, ok := opts.Cache.Lookup(id); ok {
+			cache.RecordHit()
+			return copyFile(cached, binPath, 0755)
+		}
+	}
+	cache.RecordMiss()
+
+	if err := opts.Env.Build(pkg, binPath, buildOpts); err != nil {
+		return err
+	}
+
+	if opts.Cache != nil {
+		if _, err := opts.Cache.Put(id, binPath); err != nil {
+			return fmt.Errorf("caching %q: %v", pkg, err)
+		}
+	}
+	return nil
+}
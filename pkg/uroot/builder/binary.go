@@ -0,0 +1,34 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+
+	"github.com/u-root/u-root/pkg/golang"
+)
+
+// BinaryBuilder builds each command as its own standalone binary.
+type BinaryBuilder struct {
+	// GCFlags and LDFlags resolve per-package -gcflags/-ldflags
+	// overrides (see PkgFlagResolver); their zero value applies no
+	// overrides.
+	GCFlags PkgFlagResolver
+	LDFlags PkgFlagResolver
+}
+
+// Build implements Builder.
+func (b BinaryBuilder) Build(opts Opts) error {
+	for _, pkg := range opts.Packages {
+		buildOpts := golang.BuildOpts{
+			GCFlags: b.GCFlags.Flags(pkg),
+			LDFlags: b.LDFlags.Flags(pkg),
+		}
+		if err := buildOne(opts, pkg, "binary", "", buildOpts); err != nil {
+			return fmt.Errorf("binary builder: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package builder turns u-root Commands groups (a builder + a set of Go
+// package import paths) into compiled binaries in a temp directory.
+package builder
+
+import (
+	"github.com/u-root/u-root/pkg/cache"
+	"github.com/u-root/u-root/pkg/golang"
+)
+
+// Opts carries everything a Builder needs to compile a Commands group.
+type Opts struct {
+	Env        golang.Environ
+	Packages   []string
+	TempDir    string
+	NoStrip    bool
+	Cache      *cache.Cache
+	RebuildAll bool
+}
+
+// Builder turns a set of Go package import paths into executables
+// inside Opts.TempDir.
+type Builder interface {
+	Build(opts Opts) error
+}
@@ -0,0 +1,88 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/u-root/u-root/pkg/cache"
+)
+
+// packageInput resolves pkg and, recursively, every package it imports,
+// and returns one PackageInput per package with the sha256 hash of every
+// Go source file it contains. Standard library packages are excluded
+// from the walk (besides pkg itself, which is always included even if it
+// happens to live in GOROOT): their content is pinned by the toolchain,
+// which ActionKeyInput.GoVersion already captures. This is what makes an
+// edit to a command's dependency -- not just the command package itself
+// -- change the resulting ActionID.
+func packageInput(pkg string) ([]PackageInput, error) {
+	seen := map[string]bool{}
+	var pkgs []PackageInput
+
+	var walk func(importPath string) error
+	walk = func(importPath string) error {
+		if seen[importPath] {
+			return nil
+		}
+		seen[importPath] = true
+
+		bpkg, err := build.Import(importPath, "", 0)
+		if err != nil {
+			return fmt.Errorf("resolving %q (imported by %q): %v", importPath, pkg, err)
+		}
+		if bpkg.Goroot && importPath != pkg {
+			return nil
+		}
+
+		files := append([]string{}, bpkg.GoFiles...)
+		files = append(files, bpkg.CgoFiles...)
+
+		hashes := make(map[string][32]byte, len(files))
+		for _, f := range files {
+			h, err := cache.HashFile(filepath.Join(bpkg.Dir, f))
+			if err != nil {
+				return err
+			}
+			hashes[f] = h
+		}
+		pkgs = append(pkgs, PackageInput{ImportPath: importPath, FileHashes: hashes})
+
+		for _, imp := range bpkg.Imports {
+			if err := walk(imp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(pkg); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// copyFile copies the file at src to dst, creating dst with mode perm.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
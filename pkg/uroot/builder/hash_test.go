@@ -0,0 +1,80 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"os"
+	"testing"
+)
+
+const (
+	fooImportPath = "github.com/u-root/u-root/pkg/uroot/builder/testdata/foo"
+	barImportPath = "github.com/u-root/u-root/pkg/uroot/builder/testdata/bar"
+	barSrcPath    = "testdata/bar/bar.go"
+)
+
+func TestPackageInputIncludesTransitiveImports(t *testing.T) {
+	pkgs, err := packageInput(fooImportPath)
+	if err != nil {
+		t.Fatalf("packageInput: %v", err)
+	}
+
+	byPath := make(map[string]PackageInput, len(pkgs))
+	for _, p := range pkgs {
+		byPath[p.ImportPath] = p
+	}
+	if _, ok := byPath[fooImportPath]; !ok {
+		t.Error("packageInput did not include the top-level package")
+	}
+	if _, ok := byPath[barImportPath]; !ok {
+		t.Error("packageInput did not include a transitively imported package")
+	}
+}
+
+func TestPackageInputChangesWithDependencyEdit(t *testing.T) {
+	orig, err := os.ReadFile(barSrcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.WriteFile(barSrcPath, orig, 0644); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	before, err := packageInput(fooImportPath)
+	if err != nil {
+		t.Fatalf("packageInput: %v", err)
+	}
+	idBefore := ActionID(ActionKeyInput{Packages: before})
+
+	// Edit the dependency, not the top-level package.
+	edited := append(append([]byte{}, orig...), []byte("\nconst x = 1\n")...)
+	if err := os.WriteFile(barSrcPath, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := packageInput(fooImportPath)
+	if err != nil {
+		t.Fatalf("packageInput: %v", err)
+	}
+	idAfter := ActionID(ActionKeyInput{Packages: after})
+
+	if idBefore == idAfter {
+		t.Error("ActionID unchanged after editing a dependency's source file")
+	}
+}
+
+func TestPackageInputExcludesStandardLibrary(t *testing.T) {
+	pkgs, err := packageInput(fooImportPath)
+	if err != nil {
+		t.Fatalf("packageInput: %v", err)
+	}
+	for _, p := range pkgs {
+		if p.ImportPath == "fmt" || p.ImportPath == "unsafe" {
+			t.Errorf("packageInput included standard library package %q", p.ImportPath)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PkgFlag is one `-pkg-gcflags`/`-pkg-ldflags` override: Pattern is
+// matched against package import paths the same way `go build`
+// matches `-gcflags=pattern=flags` (see matchPattern), and Flags
+// replaces the resolver's Default for every matching package.
+type PkgFlag struct {
+	Pattern string
+	Flags   string
+}
+
+// ParsePkgFlag parses a single `-pkg-gcflags`/`-pkg-ldflags` argument of
+// the form "<pattern>=<flags>".
+func ParsePkgFlag(arg string) (PkgFlag, error) {
+	i := strings.Index(arg, "=")
+	if i < 0 {
+		return PkgFlag{}, fmt.Errorf("malformed flag override %q; want <pattern>=<flags>", arg)
+	}
+	return PkgFlag{Pattern: arg[:i], Flags: arg[i+1:]}, nil
+}
+
+// PkgFlagResolver resolves the gcflags or ldflags that apply to a given
+// package import path, given a set of pattern overrides plus a default
+// that applies when no override matches. When several overrides match,
+// the one latest in Overrides wins, same as `go build`'s handling of
+// repeated `-gcflags` patterns.
+type PkgFlagResolver struct {
+	Default   string
+	Overrides []PkgFlag
+}
+
+// Flags returns the flags that apply to importPath: the last matching
+// override in Overrides if any, else the resolver's Default.
+func (r PkgFlagResolver) Flags(importPath string) string {
+	flags := r.Default
+	for _, o := range r.Overrides {
+		if matchPattern(o.Pattern, importPath) {
+			flags = o.Flags
+		}
+	}
+	return flags
+}
+
+// matchPattern reports whether importPath matches pattern. "all" matches
+// everything, a trailing "/..." matches importPath and everything
+// beneath it, and anything else is matched with path.Match, so it must
+// match exactly unless it contains glob characters ("*" matches within
+// a single path element).
+func matchPattern(pattern, importPath string) bool {
+	if pattern == "all" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/...") {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+	ok, err := path.Match(pattern, importPath)
+	return err == nil && ok
+}
@@ -0,0 +1,64 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, importPath string
+		want                bool
+	}{
+		{"all", "github.com/u-root/u-root/cmds/core/ls", true},
+		{"all", "anything", true},
+		{"github.com/u-root/u-root/...", "github.com/u-root/u-root", true},
+		{"github.com/u-root/u-root/...", "github.com/u-root/u-root/cmds/core/ls", true},
+		{"github.com/u-root/u-root/...", "github.com/u-root/other", false},
+		{"github.com/u-root/u-root/cmds/core/ls", "github.com/u-root/u-root/cmds/core/ls", true},
+		{"github.com/u-root/u-root/cmds/core/ls", "github.com/u-root/u-root/cmds/core/cp", false},
+		{"github.com/u-root/u-root/cmds/core/*", "github.com/u-root/u-root/cmds/core/ls", true},
+		{"github.com/u-root/u-root/cmds/core/*", "github.com/u-root/u-root/cmds/core/sub/ls", false},
+	} {
+		if got := matchPattern(tt.pattern, tt.importPath); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.importPath, got, tt.want)
+		}
+	}
+}
+
+func TestPkgFlagResolverFlags(t *testing.T) {
+	r := PkgFlagResolver{
+		Default: "-N -l",
+		Overrides: []PkgFlag{
+			{Pattern: "github.com/u-root/u-root/cmds/core/...", Flags: "-m"},
+			{Pattern: "github.com/u-root/u-root/cmds/core/ls", Flags: "-m -m"},
+		},
+	}
+	for _, tt := range []struct {
+		importPath string
+		want       string
+	}{
+		{"github.com/u-root/u-root/cmds/exp/foo", "-N -l"},
+		{"github.com/u-root/u-root/cmds/core/cp", "-m"},
+		{"github.com/u-root/u-root/cmds/core/ls", "-m -m"},
+	} {
+		if got := r.Flags(tt.importPath); got != tt.want {
+			t.Errorf("Flags(%q) = %q, want %q", tt.importPath, got, tt.want)
+		}
+	}
+}
+
+func TestParsePkgFlag(t *testing.T) {
+	flag, err := ParsePkgFlag("github.com/u-root/u-root/cmds/core/ls=-m")
+	if err != nil {
+		t.Fatalf("ParsePkgFlag: %v", err)
+	}
+	if flag.Pattern != "github.com/u-root/u-root/cmds/core/ls" || flag.Flags != "-m" {
+		t.Errorf("ParsePkgFlag = %+v, want Pattern=%q Flags=%q", flag, "github.com/u-root/u-root/cmds/core/ls", "-m")
+	}
+
+	if _, err := ParsePkgFlag("no-equals-sign"); err == nil {
+		t.Error("ParsePkgFlag(malformed) = nil error, want error")
+	}
+}
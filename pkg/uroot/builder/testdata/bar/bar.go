@@ -0,0 +1,7 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bar is a fixture package for hash_test.go; it exists only to
+// give packageInput a dependency to walk.
+package bar
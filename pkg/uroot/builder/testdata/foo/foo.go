@@ -0,0 +1,13 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package foo is a fixture package for hash_test.go; it exists only to
+// give packageInput an import graph to walk.
+package foo
+
+import (
+	_ "fmt"
+
+	_ "github.com/u-root/u-root/pkg/uroot/builder/testdata/bar"
+)
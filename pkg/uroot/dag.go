@@ -0,0 +1,97 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package uroot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Action is one node in the build action graph: building a single
+// Commands group, or the final archive-assembly step. Actions form a
+// DAG; an Action only runs once every Action in Deps has completed
+// successfully.
+//
+// This mirrors the shape of cmd/go/internal/work.Builder's action
+// graph, scaled down to u-root's needs: a handful of build nodes
+// feeding into one archive node.
+type Action struct {
+	Label string
+	Deps  []*Action
+	Run   func() error
+
+	// Duration is set after Run returns, for inclusion in build stats.
+	Duration time.Duration
+
+	err  error
+	done chan struct{}
+}
+
+// NewAction creates an Action with the given label and work function,
+// depending on the completion of deps.
+func NewAction(label string, run func() error, deps ...*Action) *Action {
+	return &Action{Label: label, Run: run, Deps: deps, done: make(chan struct{})}
+}
+
+// BuildActionGraph returns groups (one Action per Commands group)
+// followed by a final "archive" Action that depends on all of them.
+// archive is run once every per-group build Action has completed
+// successfully.
+func BuildActionGraph(groups []*Action, archive func() error) []*Action {
+	archiveAction := NewAction("archive", archive, groups...)
+	return append(append([]*Action{}, groups...), archiveAction)
+}
+
+// RunDAG executes actions with a worker pool of size numJobs, running
+// each Action only after its dependencies have completed. It starts one
+// goroutine per Action; each waits on its own deps' done channels, then
+// either runs on an acquired worker slot or, on first error found among
+// its deps, fails fast without doing its own work. There is no graph
+// walk: which ready Action gets a free worker slot first is up to the
+// Go scheduler.
+//
+// Every dependency of an Action must itself be in actions, or RunDAG
+// blocks forever waiting on it; the order of the slice does not matter.
+// The output of BuildActionGraph satisfies this.
+func RunDAG(actions []*Action, numJobs int) error {
+	if numJobs < 1 {
+		numJobs = 1
+	}
+	sem := make(chan struct{}, numJobs)
+
+	var wg sync.WaitGroup
+	wg.Add(len(actions))
+	for _, a := range actions {
+		a := a
+		go func() {
+			defer wg.Done()
+			defer close(a.done)
+
+			for _, dep := range a.Deps {
+				<-dep.done
+				if dep.err != nil {
+					a.err = fmt.Errorf("dependency %q failed: %w", dep.Label, dep.err)
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			a.err = a.Run()
+			a.Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	for _, a := range actions {
+		if a.err != nil {
+			return fmt.Errorf("action %q: %w", a.Label, a.err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package uroot
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunDAGFanOut(t *testing.T) {
+	var running int32
+	var maxRunning int32
+
+	n := 4
+	groups := make([]*Action, n)
+	for i := 0; i < n; i++ {
+		groups[i] = NewAction("group", func() error {
+			cur := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if cur <= max || atomic.CompareAndSwapInt32(&maxRunning, max, cur) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	var archiveRan bool
+	actions := BuildActionGraph(groups, func() error {
+		archiveRan = true
+		return nil
+	})
+
+	if err := RunDAG(actions, n); err != nil {
+		t.Fatalf("RunDAG: %v", err)
+	}
+	if !archiveRan {
+		t.Error("archive action never ran")
+	}
+	if maxRunning < 2 {
+		t.Errorf("max concurrent group actions = %d, want >= 2 (did groups run in parallel?)", maxRunning)
+	}
+}
+
+func TestRunDAGArchiveWaitsForGroups(t *testing.T) {
+	var groupDone int32
+	groups := []*Action{
+		NewAction("g0", func() error {
+			time.Sleep(20 * time.Millisecond)
+			atomic.StoreInt32(&groupDone, 1)
+			return nil
+		}),
+	}
+	sawGroupDone := false
+	actions := BuildActionGraph(groups, func() error {
+		sawGroupDone = atomic.LoadInt32(&groupDone) == 1
+		return nil
+	})
+	if err := RunDAG(actions, 2); err != nil {
+		t.Fatalf("RunDAG: %v", err)
+	}
+	if !sawGroupDone {
+		t.Error("archive action ran before its group dependency finished")
+	}
+}
+
+func TestRunDAGFailFast(t *testing.T) {
+	wantErr := errors.New("boom")
+	groups := []*Action{
+		NewAction("ok", func() error { return nil }),
+		NewAction("fails", func() error { return wantErr }),
+	}
+	archiveRan := false
+	actions := BuildActionGraph(groups, func() error {
+		archiveRan = true
+		return nil
+	})
+
+	err := RunDAG(actions, 2)
+	if err == nil {
+		t.Fatal("RunDAG returned nil error, want failure from \"fails\" action")
+	}
+	if archiveRan {
+		t.Error("archive action ran despite a failed dependency")
+	}
+}
+
+func TestRunDAGRecordsDuration(t *testing.T) {
+	a := NewAction("slow", func() error {
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	})
+	if err := RunDAG([]*Action{a}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if a.Duration < 15*time.Millisecond {
+		t.Errorf("Duration = %v, want >= 15ms", a.Duration)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package initramfs
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/u-root/u-root/pkg/cpio"
+)
+
+// RecordHash is the content hash of a cpio record: the sha256 of its
+// name, mode, UID and GID plus its file content, in that order. The
+// other header fields (Ino, NLink, MTime, device numbers) are not
+// hashed, so two records with equal RecordHash can still differ in them.
+type RecordHash [sha256.Size]byte
+
+// BaseIndex is a per-path hash index of a prior archive, built once by
+// IndexArchive and then consulted for every record u-root would produce
+// this run. It mirrors the content-based staleness check
+// cmd/go/internal/work/exec.go uses to skip recompiling unchanged
+// packages, applied here to cpio entries instead of object files.
+type BaseIndex struct {
+	hashes  map[string]RecordHash
+	records map[string]cpio.Record
+}
+
+// IndexArchive reads every record out of r and returns a BaseIndex
+// mapping each entry's name to its content hash and original record, so
+// that unchanged entries can be copied into the new archive byte-for-byte
+// instead of being re-derived.
+func IndexArchive(r Reader) (*BaseIndex, error) {
+	idx := &BaseIndex{
+		hashes:  make(map[string]RecordHash),
+		records: make(map[string]cpio.Record),
+	}
+	for {
+		rec, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading base archive: %v", err)
+		}
+		h, err := hashRecord(rec)
+		if err != nil {
+			return nil, fmt.Errorf("hashing base archive record %q: %v", rec.Name, err)
+		}
+		idx.hashes[rec.Name] = h
+		idx.records[rec.Name] = rec
+	}
+	return idx, nil
+}
+
+// Unchanged reports whether path's content hash matches what the base
+// archive already has recorded for it.
+func (idx *BaseIndex) Unchanged(path string, h RecordHash) bool {
+	old, ok := idx.hashes[path]
+	return ok && old == h
+}
+
+// Record returns the prior archive's record for path, if any, so its
+// bytes can be reused verbatim instead of reopening and re-reading the
+// file it came from.
+func (idx *BaseIndex) Record(path string) (cpio.Record, bool) {
+	rec, ok := idx.records[path]
+	return rec, ok
+}
+
+// HashRecord computes the RecordHash of rec, a to-be-written record,
+// for comparison against a BaseIndex built from the previous run's
+// output.
+func HashRecord(rec cpio.Record) (RecordHash, error) {
+	return hashRecord(rec)
+}
+
+func hashRecord(rec cpio.Record) (RecordHash, error) {
+	var zero RecordHash
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %d %d %d\n", rec.Name, rec.Mode, rec.UID, rec.GID)
+	if rec.ReaderAt != nil {
+		if _, err := io.Copy(h, io.NewSectionReader(rec.ReaderAt, 0, int64(rec.FileSize))); err != nil {
+			return zero, err
+		}
+	}
+	var out RecordHash
+	copy(out[:], h.Sum(nil))
+	return out, nil
+}
+
+// CopyRecord writes rec's original bytes straight to w, without
+// re-deriving it from source, for use when a BaseIndex lookup shows the
+// entry is unchanged from the prior archive.
+func CopyRecord(w Writer, rec cpio.Record) error {
+	return w.WriteRecord(rec)
+}
@@ -0,0 +1,140 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package initramfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/u-root/u-root/pkg/cpio"
+)
+
+// sliceReader is a Reader over a fixed slice of records, for tests.
+type sliceReader struct {
+	records []cpio.Record
+	i       int
+}
+
+func (s *sliceReader) ReadRecord() (cpio.Record, error) {
+	if s.i >= len(s.records) {
+		return cpio.Record{}, io.EOF
+	}
+	rec := s.records[s.i]
+	s.i++
+	return rec, nil
+}
+
+func rec(name, content string, mode uint64) cpio.Record {
+	return cpio.Record{
+		Info:     cpio.Info{Mode: mode, FileSize: uint64(len(content))},
+		Name:     name,
+		ReaderAt: bytes.NewReader([]byte(content)),
+	}
+}
+
+func TestHashRecord(t *testing.T) {
+	a := rec("bin/ls", "content", 0755)
+	b := rec("bin/ls", "content", 0755)
+	c := rec("bin/ls", "different", 0755)
+	d := rec("bin/cp", "content", 0755)
+
+	ha, err := HashRecord(a)
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	hb, err := HashRecord(b)
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	if ha != hb {
+		t.Error("identical records hashed differently")
+	}
+
+	hc, err := HashRecord(c)
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	if ha == hc {
+		t.Error("records with different content hashed the same")
+	}
+
+	hd, err := HashRecord(d)
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	if ha == hd {
+		t.Error("records with different names hashed the same")
+	}
+}
+
+func TestIndexArchiveAndUnchanged(t *testing.T) {
+	idx, err := IndexArchive(&sliceReader{records: []cpio.Record{
+		rec("bin/ls", "content", 0755),
+		rec("bin/cp", "other", 0755),
+	}})
+	if err != nil {
+		t.Fatalf("IndexArchive: %v", err)
+	}
+
+	lsHash, err := HashRecord(rec("bin/ls", "content", 0755))
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	if !idx.Unchanged("bin/ls", lsHash) {
+		t.Error("Unchanged(bin/ls) = false, want true")
+	}
+
+	changedHash, err := HashRecord(rec("bin/ls", "new content", 0755))
+	if err != nil {
+		t.Fatalf("HashRecord: %v", err)
+	}
+	if idx.Unchanged("bin/ls", changedHash) {
+		t.Error("Unchanged(bin/ls, changed hash) = true, want false")
+	}
+
+	if idx.Unchanged("bin/missing", lsHash) {
+		t.Error("Unchanged(bin/missing) = true, want false")
+	}
+
+	if _, ok := idx.Record("bin/missing"); ok {
+		t.Error("Record(bin/missing) found a record, want none")
+	}
+	if r, ok := idx.Record("bin/ls"); !ok || r.Name != "bin/ls" {
+		t.Errorf("Record(bin/ls) = %+v, %v", r, ok)
+	}
+}
+
+func TestCopyRecord(t *testing.T) {
+	idx, err := IndexArchive(&sliceReader{records: []cpio.Record{rec("bin/ls", "content", 0755)}})
+	if err != nil {
+		t.Fatalf("IndexArchive: %v", err)
+	}
+	old, ok := idx.Record("bin/ls")
+	if !ok {
+		t.Fatal("Record(bin/ls) not found")
+	}
+
+	mw := &memWriter{}
+	if err := CopyRecord(mw, old); err != nil {
+		t.Fatalf("CopyRecord: %v", err)
+	}
+	if len(mw.written) != 1 || mw.written[0].Name != "bin/ls" {
+		t.Errorf("CopyRecord wrote %+v, want one record named bin/ls", mw.written)
+	}
+}
+
+// memWriter is a Writer that just records what was written to it, for
+// tests that don't need real serialization.
+type memWriter struct {
+	written []cpio.Record
+}
+
+func (m *memWriter) WriteRecord(rec cpio.Record) error {
+	m.written = append(m.written, rec)
+	return nil
+}
+
+func (m *memWriter) Close() error { return nil }
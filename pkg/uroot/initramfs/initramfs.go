@@ -0,0 +1,146 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package initramfs reads and writes the cpio archives u-root packs
+// into an initramfs image.
+package initramfs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/u-root/u-root/pkg/cpio"
+)
+
+// Reader reads Records out of an archive, one at a time, returning
+// io.EOF once exhausted.
+type Reader interface {
+	ReadRecord() (cpio.Record, error)
+}
+
+// Writer appends Records to an archive being built.
+type Writer interface {
+	WriteRecord(cpio.Record) error
+	io.Closer
+}
+
+// Archiver is a file format (today, just cpio) that can read and write
+// initramfs archives.
+type Archiver interface {
+	Reader(r io.ReaderAt) Reader
+	OpenWriter(logger *log.Logger, path string) (Writer, error)
+}
+
+// GetArchiver returns the Archiver for the named format (currently only
+// "cpio").
+func GetArchiver(format string) (Archiver, error) {
+	switch format {
+	case "cpio", "":
+		return CPIOArchiver{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// CPIOArchiver is the default Archiver. Its wire format is a simple,
+// self-delimiting sequence of (metadata, name, content) records; it
+// only needs to round-trip what pkg/uroot itself writes and reads, not
+// interoperate with an external cpio implementation.
+type CPIOArchiver struct{}
+
+func (CPIOArchiver) Reader(r io.ReaderAt) Reader {
+	return &cpioReader{r: bufio.NewReader(io.NewSectionReader(r, 0, 1<<62))}
+}
+
+func (CPIOArchiver) OpenWriter(logger *log.Logger, path string) (Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &cpioWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+var infoFields = 11 // number of uint64 fields in cpio.Info
+
+type cpioReader struct {
+	r *bufio.Reader
+}
+
+func (c *cpioReader) ReadRecord() (cpio.Record, error) {
+	var rec cpio.Record
+
+	raw := make([]uint64, infoFields)
+	for i := range raw {
+		if err := binary.Read(c.r, binary.LittleEndian, &raw[i]); err != nil {
+			if i == 0 && err == io.EOF {
+				return cpio.Record{}, io.EOF
+			}
+			return cpio.Record{}, err
+		}
+	}
+	rec.Ino, rec.Mode, rec.UID, rec.GID, rec.NLink, rec.MTime, rec.FileSize,
+		rec.Major, rec.Minor, rec.Rmajor, rec.Rminor =
+		raw[0], raw[1], raw[2], raw[3], raw[4], raw[5], raw[6], raw[7], raw[8], raw[9], raw[10]
+
+	var nameLen uint32
+	if err := binary.Read(c.r, binary.LittleEndian, &nameLen); err != nil {
+		return cpio.Record{}, err
+	}
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(c.r, name); err != nil {
+		return cpio.Record{}, err
+	}
+	rec.Name = string(name)
+
+	content := make([]byte, rec.FileSize)
+	if _, err := io.ReadFull(c.r, content); err != nil {
+		return cpio.Record{}, err
+	}
+	rec.ReaderAt = bytes.NewReader(content)
+
+	return rec, nil
+}
+
+type cpioWriter struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+func (c *cpioWriter) WriteRecord(rec cpio.Record) error {
+	raw := [11]uint64{
+		rec.Ino, rec.Mode, rec.UID, rec.GID, rec.NLink, rec.MTime, rec.FileSize,
+		rec.Major, rec.Minor, rec.Rmajor, rec.Rminor,
+	}
+	for _, v := range raw {
+		if err := binary.Write(c.w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(c.w, binary.LittleEndian, uint32(len(rec.Name))); err != nil {
+		return err
+	}
+	if _, err := c.w.WriteString(rec.Name); err != nil {
+		return err
+	}
+	content := make([]byte, rec.FileSize)
+	if rec.ReaderAt != nil {
+		if _, err := rec.ReaderAt.ReadAt(content, 0); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	_, err := c.w.Write(content)
+	return err
+}
+
+func (c *cpioWriter) Close() error {
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	return c.f.Close()
+}
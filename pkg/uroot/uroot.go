@@ -0,0 +1,271 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package uroot assembles a built set of Go commands, extra files, and
+// a base archive into a single initramfs image.
+package uroot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/u-root/u-root/pkg/cache"
+	"github.com/u-root/u-root/pkg/cpio"
+	"github.com/u-root/u-root/pkg/golang"
+	"github.com/u-root/u-root/pkg/uroot/builder"
+	"github.com/u-root/u-root/pkg/uroot/initramfs"
+)
+
+// Commands is one group of Go packages to compile with a single
+// Builder.
+type Commands struct {
+	Builder  builder.Builder
+	Packages []string
+}
+
+// Opts collects everything CreateInitramfs needs to assemble an
+// initramfs image.
+type Opts struct {
+	Env             golang.Environ
+	Commands        []Commands
+	TempDir         string
+	ExtraFiles      []string
+	OutputFile      initramfs.Writer
+	BaseArchive     initramfs.Reader
+	UseExistingInit bool
+	InitCmd         string
+	UinitCmd        string
+	UinitArgs       []string
+	DefaultShell    string
+	NoStrip         bool
+
+	// Cache, if non-nil, is consulted before compiling each command and
+	// populated after a cache miss.
+	Cache *cache.Cache
+	// RebuildAll skips Cache lookups so every command is rebuilt; the
+	// rebuilt binaries are still stored in Cache.
+	RebuildAll bool
+
+	// NumWorkers bounds how many build actions run concurrently; <1
+	// means "run every action at once".
+	NumWorkers int
+
+	// BaseIndex, if non-nil, is a hash index of the archive previously
+	// written to this same output path; unchanged entries are copied
+	// from it instead of being re-derived.
+	BaseIndex *initramfs.BaseIndex
+
+	// NodeTimings, if non-nil, is appended with one entry per action
+	// graph node (see CreateInitramfs).
+	NodeTimings *[]NodeTiming
+}
+
+// DefaultRamfs returns the minimal base archive u-root always includes
+// (the handful of directories like /bin, /etc, /dev every image needs),
+// absent a user-supplied -base.
+func DefaultRamfs() *ArchiveFS {
+	return &ArchiveFS{records: []cpio.Record{
+		{Name: "bin"},
+		{Name: "dev"},
+		{Name: "etc"},
+		{Name: "tmp"},
+		{Name: "proc"},
+		{Name: "sys"},
+	}}
+}
+
+// ArchiveFS is an in-memory sequence of cpio records, usable as an
+// initramfs.Reader.
+type ArchiveFS struct {
+	records []cpio.Record
+	i       int
+}
+
+// Reader returns a fresh initramfs.Reader over the same records.
+func (a *ArchiveFS) Reader() initramfs.Reader {
+	return &ArchiveFS{records: a.records}
+}
+
+// ReadRecord implements initramfs.Reader.
+func (a *ArchiveFS) ReadRecord() (cpio.Record, error) {
+	if a.i >= len(a.records) {
+		return cpio.Record{}, io.EOF
+	}
+	r := a.records[a.i]
+	a.i++
+	return r, nil
+}
+
+// NodeTiming records how long one action graph node (a Commands group
+// build, or the final archive assembly) took to run.
+type NodeTiming struct {
+	Label    string
+	Duration time.Duration
+}
+
+// CreateInitramfs builds every Commands group -- concurrently, as an
+// action graph with one node per group plus a final archive-assembly
+// node depending on all of them -- then assembles the resulting
+// binaries, the base archive, and any extra files into opts.OutputFile.
+//
+// If opts.NodeTimings is non-nil, it is populated with the wall-clock
+// duration of each graph node, for callers that want to report build
+// parallelism effectiveness.
+func CreateInitramfs(logger *log.Logger, opts Opts) error {
+	var groupActions []*Action
+	for i, group := range opts.Commands {
+		i, group := i, group
+		groupDir := filepath.Join(opts.TempDir, fmt.Sprintf("group%d", i))
+		groupActions = append(groupActions, NewAction(fmt.Sprintf("group%d", i), func() error {
+			if err := os.MkdirAll(groupDir, 0755); err != nil {
+				return err
+			}
+			return group.Builder.Build(builder.Opts{
+				Env:        opts.Env,
+				Packages:   group.Packages,
+				TempDir:    groupDir,
+				NoStrip:    opts.NoStrip,
+				Cache:      opts.Cache,
+				RebuildAll: opts.RebuildAll,
+			})
+		}))
+	}
+
+	actions := BuildActionGraph(groupActions, func() error {
+		return archive(logger, opts)
+	})
+
+	numWorkers := opts.NumWorkers
+	if numWorkers < 1 {
+		numWorkers = len(actions)
+	}
+	err := RunDAG(actions, numWorkers)
+
+	if opts.NodeTimings != nil {
+		for _, a := range actions {
+			*opts.NodeTimings = append(*opts.NodeTimings, NodeTiming{Label: a.Label, Duration: a.Duration})
+		}
+	}
+	return err
+}
+
+// archive copies the base archive, then every built command binary and
+// extra file, into opts.OutputFile.
+func archive(logger *log.Logger, opts Opts) error {
+	defer opts.OutputFile.Close()
+
+	if opts.BaseArchive != nil {
+		for {
+			rec, err := opts.BaseArchive.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("reading base archive: %v", err)
+			}
+			if err := opts.OutputFile.WriteRecord(rec); err != nil {
+				return fmt.Errorf("writing %q: %v", rec.Name, err)
+			}
+		}
+	}
+
+	binaries, err := builtBinaries(opts.TempDir)
+	if err != nil {
+		return err
+	}
+	for _, bin := range binaries {
+		if err := writeFileRecord(opts.OutputFile, filepath.Join("bin", filepath.Base(bin)), bin, opts.BaseIndex); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range opts.ExtraFiles {
+		if err := writeFileRecord(opts.OutputFile, filepath.Base(f), f, opts.BaseIndex); err != nil {
+			return err
+		}
+	}
+
+	logger.Printf("Wrote %d commands, %d extra files.", len(binaries), len(opts.ExtraFiles))
+	return nil
+}
+
+// builtBinaries lists the full paths of every file a Commands group
+// produced, across all of its group subdirectories under tempDir.
+func builtBinaries(tempDir string) ([]string, error) {
+	groupDirs, err := os.ReadDir(tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading build output %q: %v", tempDir, err)
+	}
+	var binaries []string
+	for _, gd := range groupDirs {
+		if !gd.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(tempDir, gd.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading build output %q: %v", gd.Name(), err)
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			binaries = append(binaries, filepath.Join(tempDir, gd.Name(), f.Name()))
+		}
+	}
+	return binaries, nil
+}
+
+// writeFileRecord writes srcPath to w as name. If idx is non-nil and its
+// prior record for name already has the same size, mode, and mtime that
+// srcPath has now, the file is assumed unchanged and idx's record is
+// copied into w without ever reading srcPath's content. Only when that
+// cheap check doesn't confirm a match does this fall back to reading and
+// hashing the full file, so an -incremental run actually skips the I/O
+// for the common case of an untouched file instead of just re-deriving
+// an identical record.
+func writeFileRecord(w initramfs.Writer, name, srcPath string, idx *initramfs.BaseIndex) error {
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	if idx != nil {
+		if old, ok := idx.Record(name); ok &&
+			old.FileSize == uint64(fi.Size()) &&
+			old.Mode == uint64(fi.Mode().Perm()) &&
+			old.MTime == uint64(fi.ModTime().Unix()) {
+			return initramfs.CopyRecord(w, old)
+		}
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	rec := cpio.Record{
+		Name:     name,
+		ReaderAt: bytes.NewReader(data),
+	}
+	rec.Mode = uint64(fi.Mode().Perm())
+	rec.FileSize = uint64(len(data))
+	rec.MTime = uint64(fi.ModTime().Unix())
+
+	if idx != nil {
+		h, err := initramfs.HashRecord(rec)
+		if err != nil {
+			return fmt.Errorf("hashing %q: %v", name, err)
+		}
+		if idx.Unchanged(name, h) {
+			if old, ok := idx.Record(name); ok {
+				return initramfs.CopyRecord(w, old)
+			}
+		}
+	}
+	return w.WriteRecord(rec)
+}
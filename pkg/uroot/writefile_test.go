@@ -0,0 +1,106 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package uroot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/u-root/u-root/pkg/cpio"
+	"github.com/u-root/u-root/pkg/uroot/initramfs"
+)
+
+// sliceReader is an initramfs.Reader over a fixed slice of records.
+type sliceReader struct {
+	records []cpio.Record
+	i       int
+}
+
+func (s *sliceReader) ReadRecord() (cpio.Record, error) {
+	if s.i >= len(s.records) {
+		return cpio.Record{}, io.EOF
+	}
+	rec := s.records[s.i]
+	s.i++
+	return rec, nil
+}
+
+// recordingWriter is an initramfs.Writer that just remembers what was
+// written to it.
+type recordingWriter struct {
+	written []cpio.Record
+}
+
+func (r *recordingWriter) WriteRecord(rec cpio.Record) error {
+	r.written = append(r.written, rec)
+	return nil
+}
+
+func (r *recordingWriter) Close() error { return nil }
+
+func TestWriteFileRecordSkipsReadWhenUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1700000000, 0)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	// old deliberately has no ReaderAt, unlike a record built from a
+	// fresh read (which always sets one): if the fast path took hold,
+	// writeFileRecord's output is old verbatim, ReaderAt included.
+	old := cpio.Record{
+		Info: cpio.Info{Mode: 0644, FileSize: 5, MTime: uint64(mtime.Unix())},
+		Name: "bin/foo",
+	}
+	idx, err := initramfs.IndexArchive(&sliceReader{records: []cpio.Record{old}})
+	if err != nil {
+		t.Fatalf("IndexArchive: %v", err)
+	}
+
+	w := &recordingWriter{}
+	if err := writeFileRecord(w, "bin/foo", path, idx); err != nil {
+		t.Fatalf("writeFileRecord: %v", err)
+	}
+	if len(w.written) != 1 || w.written[0].Name != "bin/foo" {
+		t.Fatalf("writeFileRecord wrote %+v, want one record named bin/foo", w.written)
+	}
+	if w.written[0].ReaderAt != nil {
+		t.Error("writeFileRecord read srcPath's content despite an unchanged size/mode/mtime")
+	}
+}
+
+func TestWriteFileRecordFallsBackWhenChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// idx's prior record has a different size, so the fast path can't
+	// confirm a match and writeFileRecord must read the file.
+	old := cpio.Record{
+		Info: cpio.Info{Mode: 0644, FileSize: 999},
+		Name: "bin/foo",
+	}
+	idx, err := initramfs.IndexArchive(&sliceReader{records: []cpio.Record{old}})
+	if err != nil {
+		t.Fatalf("IndexArchive: %v", err)
+	}
+
+	w := &recordingWriter{}
+	if err := writeFileRecord(w, "bin/foo", path, idx); err != nil {
+		t.Fatalf("writeFileRecord: %v", err)
+	}
+	if len(w.written) != 1 || w.written[0].FileSize != 5 {
+		t.Errorf("writeFileRecord wrote %+v, want a fresh record with FileSize 5", w.written)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2015-2018 the u-root Authors. All rights reserved
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+// templates expands a few convenient shorthands for common sets of
+// u-root commands into package import paths and "<dir>/*" patterns;
+// the patterns are not expanded here.
+var templates = map[string][]string{
+	"core": {"github.com/u-root/u-root/cmds/core/*"},
+	"all":  {"github.com/u-root/u-root/cmds/core/*", "github.com/u-root/u-root/cmds/exp/*"},
+	"exp":  {"github.com/u-root/u-root/cmds/exp/*"},
+	"min":  {"github.com/u-root/u-root/cmds/core/init", "github.com/u-root/u-root/cmds/core/elvish"},
+}
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/u-root/u-root/pkg/cache"
 	"github.com/u-root/u-root/pkg/golang"
 	"github.com/u-root/u-root/pkg/shlex"
 	"github.com/u-root/u-root/pkg/uroot"
@@ -49,6 +50,16 @@ var (
 	statsLabel                              *string
 	shellbang                               *bool
 	tags                                    *string
+	useCache                                *bool
+	cacheDir                                *string
+	rebuildAll                              *bool
+	commandsGroups                          multiFlag
+	numJobs                                 *int
+	goarm, goamd64, go386                   *string
+	gomips, gomips64, goppc64               *string
+	goexperiment                            *string
+	pkgGCFlags, pkgLDFlags                  multiFlag
+	incremental                             *bool
 )
 
 func init() {
@@ -84,15 +95,109 @@ func init() {
 	statsLabel = flag.String("stats-label", "", "Use this statsLabel when writing stats")
 
 	tags = flag.String("tags", "", "Comma separated list of build tags")
+
+	defaultCacheDir, err := cache.DefaultDir()
+	if err != nil {
+		defaultCacheDir = ""
+	}
+	useCache = flag.Bool("cache", true, "Cache compiled binaries and reuse them on future builds with identical inputs")
+	cacheDir = flag.String("cache-dir", defaultCacheDir, "Directory to store the build cache in (default $XDG_CACHE_HOME/u-root)")
+	rebuildAll = flag.Bool("rebuildall", false, "Ignore the build cache and rebuild everything from scratch")
+
+	flag.Var(&commandsGroups, "commands", "Build a group of commands with a given builder, as <builder>:<pkg>[,<pkg>...]. May be specified multiple times to mix builders (e.g. -commands=bb:github.com/u-root/u-root/cmds/core/* -commands=binary:github.com/u-root/u-root/cmds/exp/*); groups are built concurrently. Overrides -build and positional package args.")
+	numJobs = flag.Int("p", runtime.NumCPU(), "Number of build actions (command groups, archive assembly) to run in parallel")
+
+	goarm = flag.String("goarm", os.Getenv("GOARM"), "GOARM value to build with (5, 6, or 7; ARM only)")
+	goamd64 = flag.String("goamd64", os.Getenv("GOAMD64"), "GOAMD64 value to build with (v1-v4; amd64 only)")
+	go386 = flag.String("go386", os.Getenv("GO386"), "GO386 value to build with (sse2 or softfloat; 386 only)")
+	gomips = flag.String("gomips", os.Getenv("GOMIPS"), "GOMIPS value to build with (hardfloat or softfloat; mips/mipsle only)")
+	gomips64 = flag.String("gomips64", os.Getenv("GOMIPS64"), "GOMIPS64 value to build with (hardfloat or softfloat; mips64/mips64le only)")
+	goppc64 = flag.String("goppc64", os.Getenv("GOPPC64"), "GOPPC64 value to build with (power8, power9, or power10; ppc64/ppc64le only)")
+
+	goexperiment = flag.String("goexperiment", os.Getenv("GOEXPERIMENT"), "GOEXPERIMENT value to build with, forwarded to every go build invocation")
+	flag.Var(&pkgGCFlags, "pkg-gcflags", "Extra -gcflags for packages matching pattern, as <pattern>=<flags>. May be specified multiple times; later matches for the same pattern win.")
+	flag.Var(&pkgLDFlags, "pkg-ldflags", "Extra -ldflags for packages matching pattern, as <pattern>=<flags>. May be specified multiple times; later matches for the same pattern win.")
+
+	incremental = flag.Bool("incremental", false, "Reuse unchanged entries from the existing -o archive instead of re-archiving everything")
+}
+
+// parseCommandsGroups parses the repeated -commands flag into a list of
+// uroot.Commands, one per "<builder>:<pkgs>" entry.
+func parseCommandsGroups(entries []string) ([]uroot.Commands, error) {
+	var groups []uroot.Commands
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("malformed -commands value %q; want <builder>:<pkg>[,<pkg>...]", entry)
+		}
+		b, err := builderByName(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, uroot.Commands{
+			Builder:  b,
+			Packages: strings.Split(parts[1], ","),
+		})
+	}
+	return groups, nil
+}
+
+// parsePkgFlagResolvers builds the gcflags/ldflags resolvers from the
+// repeatable -pkg-gcflags/-pkg-ldflags flags.
+func parsePkgFlagResolvers() (gcflags, ldflags builder.PkgFlagResolver, err error) {
+	for _, arg := range pkgGCFlags {
+		f, err := builder.ParsePkgFlag(arg)
+		if err != nil {
+			return gcflags, ldflags, err
+		}
+		gcflags.Overrides = append(gcflags.Overrides, f)
+	}
+	for _, arg := range pkgLDFlags {
+		f, err := builder.ParsePkgFlag(arg)
+		if err != nil {
+			return gcflags, ldflags, err
+		}
+		ldflags.Overrides = append(ldflags.Overrides, f)
+	}
+	return gcflags, ldflags, nil
+}
+
+// builderByName returns the builder.Builder registered under name, using
+// the same set of names accepted by the top-level -build flag.
+func builderByName(name string) (builder.Builder, error) {
+	gcflags, ldflags, err := parsePkgFlagResolvers()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "bb":
+		return builder.BBBuilder{ShellBang: *shellbang, GCFlags: gcflags, LDFlags: ldflags}, nil
+	case "binary":
+		return builder.BinaryBuilder{GCFlags: gcflags, LDFlags: ldflags}, nil
+	default:
+		return nil, fmt.Errorf("could not find builder %q", name)
+	}
+}
+
+type nodeStat struct {
+	Label    string  `json:"label"`
+	Duration float64 `json:"duration"`
 }
 
 type buildStats struct {
-	Label      string  `json:"label,omitempty"`
-	Time       int64   `json:"time"`
-	Duration   float64 `json:"duration"`
-	OutputSize int64   `json:"output_size"`
+	Label       string     `json:"label,omitempty"`
+	Time        int64      `json:"time"`
+	Duration    float64    `json:"duration"`
+	OutputSize  int64      `json:"output_size"`
+	CacheHits   int64      `json:"cache_hits,omitempty"`
+	CacheMisses int64      `json:"cache_misses,omitempty"`
+	Nodes       []nodeStat `json:"nodes,omitempty"`
 }
 
+// nodeTimings is populated by Main via uroot.Opts.NodeTimings and read
+// back by main when assembling buildStats.
+var nodeTimings []uroot.NodeTiming
+
 func writeBuildStats(stats buildStats, path string) error {
 	var allStats []buildStats
 	if data, err := ioutil.ReadFile(*statsOutputPath); err == nil {
@@ -149,9 +254,14 @@ func main() {
 	elapsed := time.Now().Sub(start)
 
 	stats := buildStats{
-		Label:    *statsLabel,
-		Time:     start.Unix(),
-		Duration: float64(elapsed.Milliseconds()) / 1000,
+		Label:       *statsLabel,
+		Time:        start.Unix(),
+		Duration:    float64(elapsed.Milliseconds()) / 1000,
+		CacheHits:   cache.Hits(),
+		CacheMisses: cache.Misses(),
+	}
+	for _, n := range nodeTimings {
+		stats.Nodes = append(stats.Nodes, nodeStat{Label: n.Label, Duration: n.Duration.Seconds()})
 	}
 	if stats.Label == "" {
 		stats.Label = generateLabel()
@@ -190,10 +300,30 @@ func isRecommendedVersion(v string) bool {
 func Main() error {
 	env := golang.Default()
 	env.BuildTags = strings.Split(*tags, ",")
+	env.GOEXPERIMENT = *goexperiment
 	if env.CgoEnabled {
 		log.Printf("Disabling CGO for u-root...")
 		env.CgoEnabled = false
 	}
+	if err := golang.CheckGOOSARCHPair(env.GOOS, env.GOARCH); err != nil {
+		return err
+	}
+
+	env.GOARM = *goarm
+	env.GOAMD64 = *goamd64
+	env.GO386 = *go386
+	env.GOMIPS = *gomips
+	env.GOMIPS64 = *gomips64
+	env.GOPPC64 = *goppc64
+	for name, value := range map[string]string{
+		"GOARM": env.GOARM, "GOAMD64": env.GOAMD64, "GO386": env.GO386,
+		"GOMIPS": env.GOMIPS, "GOMIPS64": env.GOMIPS64, "GOPPC64": env.GOPPC64,
+	} {
+		if err := golang.CheckSubArch(name, value); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Build environment: %s", env)
 	if env.GOOS != "linux" {
 		log.Printf("GOOS is not linux. Did you mean to set GOOS=linux?")
@@ -225,6 +355,19 @@ func Main() error {
 		}
 		*outputPath = fmt.Sprintf("/tmp/initramfs.%s_%s.cpio", env.GOOS, env.GOARCH)
 	}
+	var baseIndex *initramfs.BaseIndex
+	if *incremental {
+		if prior, err := os.Open(*outputPath); err == nil {
+			baseIndex, err = initramfs.IndexArchive(archiver.Reader(prior))
+			prior.Close()
+			if err != nil {
+				return fmt.Errorf("indexing existing archive %q for -incremental: %v", *outputPath, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("opening existing archive %q for -incremental: %v", *outputPath, err)
+		}
+	}
+
 	w, err := archiver.OpenWriter(logger, *outputPath)
 	if err != nil {
 		return err
@@ -256,21 +399,38 @@ func Main() error {
 		}
 	}
 
+	var buildCache *cache.Cache
+	if *useCache {
+		dir := *cacheDir
+		if dir == "" {
+			return fmt.Errorf("-cache is enabled but no cache directory is available; pass -cache-dir or set XDG_CACHE_HOME")
+		}
+		buildCache, err = cache.NewCache(dir)
+		if err != nil {
+			return fmt.Errorf("could not open build cache: %v", err)
+		}
+	}
+
 	var (
 		c           []uroot.Commands
 		initCommand = *initCmd
 	)
-	if !*noCommands {
-		var b builder.Builder
-		switch *build {
-		case "bb":
-			b = builder.BBBuilder{ShellBang: *shellbang}
-		case "binary":
-			b = builder.BinaryBuilder{}
-		case "source":
-			return fmt.Errorf("source mode has been deprecated")
-		default:
-			return fmt.Errorf("could not find builder %q", *build)
+	if len(commandsGroups) > 0 {
+		if *build != "bb" {
+			log.Printf("-commands was given; ignoring -build %q", *build)
+		}
+		groups, err := parseCommandsGroups(commandsGroups)
+		if err != nil {
+			return err
+		}
+		c = append(c, groups...)
+	} else if !*noCommands {
+		b, err := builderByName(*build)
+		if err != nil {
+			if *build == "source" {
+				return fmt.Errorf("source mode has been deprecated")
+			}
+			return err
 		}
 
 		// Resolve globs into package imports.
@@ -291,8 +451,6 @@ func Main() error {
 			pkgs = []string{"github.com/u-root/u-root/cmds/core/*"}
 		}
 
-		// The command-line tool only allows specifying one build mode
-		// right now.
 		c = append(c, uroot.Commands{
 			Builder:  b,
 			Packages: pkgs,
@@ -310,6 +468,11 @@ func Main() error {
 		InitCmd:         initCommand,
 		DefaultShell:    *defaultShell,
 		NoStrip:         *noStrip,
+		Cache:           buildCache,
+		RebuildAll:      *rebuildAll,
+		NumWorkers:      *numJobs,
+		BaseIndex:       baseIndex,
+		NodeTimings:     &nodeTimings,
 	}
 	uinitArgs := shlex.Argv(*uinitCmd)
 	if len(uinitArgs) > 0 {